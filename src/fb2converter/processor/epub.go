@@ -7,35 +7,53 @@ import (
 	"path/filepath"
 
 	"github.com/pkg/errors"
+	"github.com/spf13/afero"
 	"go.uber.org/zap"
 )
 
 // FinalizeEPUB produces epub file out of previously saved temporary files.
 func (p *Processor) FinalizeEPUB(fname string) error {
 
-	if _, err := os.Stat(fname); err == nil {
+	if _, err := p.fs.Stat(fname); err == nil {
 		if !p.env.Debug && !p.overwrite {
 			return errors.Errorf("output file already exists: %s", fname)
 		}
 		p.env.Log.Warn("Overwriting existing file", zap.String("file", fname))
-		if err = os.Remove(fname); err != nil {
+		if err = p.fs.Remove(fname); err != nil {
 			return err
 		}
 	} else if !os.IsNotExist(err) {
 		return err
 	} else {
-		if err := os.MkdirAll(filepath.Dir(fname), 0700); err != nil {
+		if err := p.fs.MkdirAll(filepath.Dir(fname), 0700); err != nil {
 			return errors.Wrap(err, "unable to create output directory")
 		}
 	}
 
-	f, err := os.Create(fname)
+	f, err := p.fs.Create(fname)
 	if err != nil {
 		return errors.Wrapf(err, "unable to create EPUB: %s", fname)
 	}
 	defer f.Close()
 
-	epub := zip.NewWriter(f)
+	if err := p.writeEPUB(f, fname); err != nil {
+		return err
+	}
+	return nil
+}
+
+// WriteEPUB writes epub out of previously saved temporary files to w.
+func (p *Processor) WriteEPUB(w io.Writer) error {
+	return p.writeEPUB(w, "")
+}
+
+// writeEPUB is the shared implementation behind WriteEPUB and FinalizeEPUB. exclude, when
+// non-empty, is the path of the file w itself is backed by - FinalizeEPUB creates it before
+// walking, and when it lies inside p.tmpDir it must still be skipped the same way the old
+// per-path "ignore itself" check did.
+func (p *Processor) writeEPUB(w io.Writer, exclude string) error {
+
+	epub := zip.NewWriter(w)
 	defer epub.Close()
 
 	var content bool
@@ -47,7 +65,7 @@ func (p *Processor) FinalizeEPUB(fname string) error {
 		if !info.Mode().IsRegular() {
 			return nil
 		}
-		if filepath.ToSlash(path) == filepath.ToSlash(fname) {
+		if len(exclude) > 0 && filepath.ToSlash(path) == filepath.ToSlash(exclude) {
 			// ignore itself
 			return nil
 		}
@@ -63,27 +81,27 @@ func (p *Processor) FinalizeEPUB(fname string) error {
 		}
 		rel = filepath.ToSlash(rel)
 
-		var w io.Writer
+		var dst io.Writer
 		if !content {
-			if w, err = epub.CreateHeader(&zip.FileHeader{
+			if dst, err = epub.CreateHeader(&zip.FileHeader{
 				Name:   info.Name(),
 				Method: zip.Store,
 			}); err != nil {
 				return err
 			}
 		} else {
-			if w, err = epub.Create(rel); err != nil {
+			if dst, err = epub.Create(rel); err != nil {
 				return err
 			}
 		}
 
-		var r io.ReadCloser
-		if r, err = os.Open(path); err != nil {
+		var r afero.File
+		if r, err = p.fs.Open(path); err != nil {
 			return err
 		}
 		defer r.Close()
 
-		if _, err = io.Copy(w, r); err != nil {
+		if _, err = io.Copy(dst, r); err != nil {
 			return err
 		}
 		return nil
@@ -91,7 +109,7 @@ func (p *Processor) FinalizeEPUB(fname string) error {
 
 	// mimetype should be the fist entry in epub
 	mt := filepath.Join(p.tmpDir, "mimetype")
-	info, err := os.Stat(mt)
+	info, err := p.fs.Stat(mt)
 	if err != nil {
 		return errors.Wrap(err, "unable to find mimetype file")
 	}
@@ -101,7 +119,7 @@ func (p *Processor) FinalizeEPUB(fname string) error {
 
 	content = true
 
-	if err = filepath.Walk(p.tmpDir, saveFile); err != nil {
+	if err = afero.Walk(p.fs, p.tmpDir, saveFile); err != nil {
 		return errors.Wrap(err, "unable to add file to EPUB")
 	}
 	return nil
@@ -0,0 +1,75 @@
+package processor
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+func newTestProcessor(t *testing.T, tmpDir string) *Processor {
+	t.Helper()
+	p := NewProcessor(&Env{Log: zap.NewNop()}, tmpDir, false, WithFs(afero.NewMemMapFs()))
+	files := map[string]string{
+		"mimetype":                "application/epub+zip",
+		"META-INF/container.xml": "<container/>",
+		"OEBPS/content.opf":      "<package/>",
+	}
+	for name, content := range files {
+		if err := afero.WriteFile(p.fs, tmpDir+"/"+name, []byte(content), 0600); err != nil {
+			t.Fatalf("unable to seed %s: %v", name, err)
+		}
+	}
+	return p
+}
+
+func TestWriteEPUBMimetypeFirstAndStored(t *testing.T) {
+
+	p := newTestProcessor(t, "/work")
+
+	var buf bytes.Buffer
+	if err := p.WriteEPUB(&buf); err != nil {
+		t.Fatalf("WriteEPUB failed: %v", err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unable to read produced EPUB: %v", err)
+	}
+	if len(r.File) == 0 || r.File[0].Name != "mimetype" {
+		t.Fatalf("expected mimetype to be the first entry, got %v", r.File)
+	}
+	if r.File[0].Method != zip.Store {
+		t.Fatalf("expected mimetype to be stored uncompressed, got method %d", r.File[0].Method)
+	}
+}
+
+// TestFinalizeEPUBExcludesNestedOutput covers an output path nested under a
+// subdirectory of tmpDir - not the tmpDir root - which the root-only skip in
+// WriteEPUB does not catch on its own.
+func TestFinalizeEPUBExcludesNestedOutput(t *testing.T) {
+
+	p := newTestProcessor(t, "/work")
+
+	fname := "/work/OEBPS/book.epub"
+	if err := p.FinalizeEPUB(fname); err != nil {
+		t.Fatalf("FinalizeEPUB failed: %v", err)
+	}
+
+	data, err := afero.ReadFile(p.fs, fname)
+	if err != nil {
+		t.Fatalf("unable to read produced EPUB: %v", err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("unable to read produced EPUB: %v", err)
+	}
+	for _, f := range r.File {
+		if f.Name == "book.epub" || f.Name == "OEBPS/book.epub" {
+			t.Fatalf("output file was walked into itself: %v", r.File)
+		}
+	}
+}
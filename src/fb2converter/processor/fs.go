@@ -0,0 +1,10 @@
+package processor
+
+import "github.com/spf13/afero"
+
+// WithFs overrides the filesystem Processor reads and writes its files through.
+func WithFs(fs afero.Fs) Option {
+	return func(p *Processor) {
+		p.fs = fs
+	}
+}
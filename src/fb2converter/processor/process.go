@@ -0,0 +1,45 @@
+package processor
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// Process reads the source book at src and stages EPUB content for it under p.tmpDir,
+// ready for FinalizeEPUB (or the KFX/MOBI post-processing steps) to package.
+func (p *Processor) Process(src string) error {
+
+	if err := p.fs.MkdirAll(p.tmpDir, 0700); err != nil {
+		return errors.Wrap(err, "unable to create work directory")
+	}
+
+	mt := filepath.Join(p.tmpDir, "mimetype")
+	if err := afero.WriteFile(p.fs, mt, []byte("application/epub+zip"), 0600); err != nil {
+		return errors.Wrap(err, "unable to write mimetype")
+	}
+
+	dst := filepath.Join(p.tmpDir, "OEBPS", filepath.Base(src))
+	if err := p.fs.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return errors.Wrap(err, "unable to create OEBPS directory")
+	}
+
+	in, err := p.fs.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open %s", src)
+	}
+	defer in.Close()
+
+	out, err := p.fs.Create(dst)
+	if err != nil {
+		return errors.Wrapf(err, "unable to stage %s", src)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return errors.Wrapf(err, "unable to stage %s", src)
+	}
+	return nil
+}
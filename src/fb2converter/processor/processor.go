@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// Env carries logging and run-mode flags shared by every stage of the conversion pipeline.
+type Env struct {
+	Log   *zap.Logger
+	Debug bool
+}
+
+// Processor drives a single book through the fb2 to epub/kfx/mobi conversion pipeline.
+type Processor struct {
+	env       *Env
+	tmpDir    string
+	overwrite bool
+	fs        afero.Fs
+}
+
+// Option configures a Processor at construction time.
+type Option func(*Processor)
+
+// NewProcessor creates a Processor converting a single book into tmpDir, defaulting to
+// the OS filesystem unless overridden by an Option such as WithFs.
+func NewProcessor(env *Env, tmpDir string, overwrite bool, options ...Option) *Processor {
+	p := &Processor{
+		env:       env,
+		tmpDir:    tmpDir,
+		overwrite: overwrite,
+		fs:        afero.NewOsFs(),
+	}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
@@ -0,0 +1,236 @@
+package processor
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// watchDebounce is how long Watcher waits after the last event for a file before
+// treating it as settled - long enough to absorb the flurry of writes/renames most
+// editors and downloaders produce for a single save.
+const watchDebounce = 500 * time.Millisecond
+
+var watchExtensions = []string{".fb2", ".fb2.zip", ".zip"}
+
+// WatchConvertFunc is invoked once per settled source file discovered by Watcher.
+type WatchConvertFunc func(path string) error
+
+// Watcher observes one or more directories for new or modified FB2 sources and feeds
+// them to a conversion callback as they settle. It is meant for drop-folder style
+// workflows (e.g. MyHomeLib) where sources appear continuously and there is no
+// external process to invoke the converter on arrival.
+type Watcher struct {
+	log     *zap.Logger
+	convert WatchConvertFunc
+
+	fsw *fsnotify.Watcher
+
+	mu      sync.Mutex
+	timers  map[string]*time.Timer
+	rootDir map[string]bool
+	stopped bool
+}
+
+// NewWatcher creates a Watcher over roots, recursively registering every directory
+// found under each of them. convert is called for every settled file matching one of
+// the supported FB2 extensions.
+func NewWatcher(log *zap.Logger, convert WatchConvertFunc, roots ...string) (*Watcher, error) {
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create filesystem watcher")
+	}
+
+	w := &Watcher{
+		log:     log,
+		convert: convert,
+		fsw:     fsw,
+		timers:  make(map[string]*time.Timer),
+		rootDir: make(map[string]bool),
+	}
+
+	for _, root := range roots {
+		w.rootDir[root] = true
+	}
+	if err := w.rescan(); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// rescan (re)registers every directory under the configured roots, picking up
+// directories created after the watcher started and re-arming ones that were
+// removed and recreated.
+func (w *Watcher) rescan() error {
+	for root := range w.rootDir {
+		if err := w.registerTree(root); err != nil {
+			return errors.Wrapf(err, "unable to scan watch root: %s", root)
+		}
+	}
+	return nil
+}
+
+// registerTree walks root and registers it along with every directory nested under it,
+// so a directory dropped in all at once (cp -r, unzip) is picked up in full rather than
+// just at its top level.
+func (w *Watcher) registerTree(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// root may have been temporarily removed - skip it, a SIGHUP-triggered
+			// rescan will pick it up again once it reappears
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if err := w.fsw.Add(path); err != nil {
+			w.log.Warn("Unable to watch directory", zap.String("dir", path), zap.Error(err))
+		}
+		return nil
+	})
+}
+
+// Run blocks processing filesystem events until stop is closed. A single failed
+// conversion is logged and does not interrupt the loop - the watcher keeps running
+// for every other book in the drop folder. SIGHUP triggers an immediate rescan of
+// the watched roots, useful after bulk-adding directories externally. Shutdown cancels
+// every pending debounce timer, so no new conversion starts once Run has returned; one
+// that was already in flight at the instant stop closed may still finish afterwards.
+func (w *Watcher) Run(stop <-chan struct{}) error {
+
+	defer w.fsw.Close()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-stop:
+			w.stopPending()
+			return nil
+		case sig := <-hup:
+			w.log.Info("Rescanning watched directories", zap.Stringer("signal", sig))
+			if err := w.rescan(); err != nil {
+				w.log.Error("Unable to rescan watched directories", zap.Error(err))
+			}
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			w.log.Error("Filesystem watcher error", zap.Error(err))
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			w.handleEvent(ev)
+		}
+	}
+}
+
+func (w *Watcher) handleEvent(ev fsnotify.Event) {
+
+	if ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+		return
+	}
+
+	info, err := os.Stat(ev.Name)
+	if err != nil {
+		return
+	}
+
+	if info.IsDir() {
+		if ev.Op&fsnotify.Create != 0 {
+			if err := w.registerTree(ev.Name); err != nil {
+				w.log.Warn("Unable to watch directory", zap.String("dir", ev.Name), zap.Error(err))
+			}
+		}
+		return
+	}
+
+	if !isWatchedSource(ev.Name) {
+		return
+	}
+	w.debounce(ev.Name)
+}
+
+// debounce coalesces the burst of write events a single save typically produces into
+// one conversion, firing watchDebounce after the last observed event for path.
+func (w *Watcher) debounce(path string) {
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return
+	}
+
+	if t, ok := w.timers[path]; ok {
+		t.Stop()
+	}
+	w.timers[path] = time.AfterFunc(watchDebounce, func() {
+		w.mu.Lock()
+		if w.stopped {
+			w.mu.Unlock()
+			return
+		}
+		delete(w.timers, path)
+		w.mu.Unlock()
+
+		if err := w.convert(path); err != nil {
+			w.log.Error("Unable to convert book", zap.String("file", path), zap.Error(err))
+		}
+	})
+}
+
+// stopPending cancels every pending debounce timer and blocks any new one from being
+// armed, so no conversion can start once Run has returned.
+func (w *Watcher) stopPending() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stopped = true
+	for path, t := range w.timers {
+		t.Stop()
+		delete(w.timers, path)
+	}
+}
+
+// ConvertOnWatch wires a Watcher straight into the conversion pipeline: every settled
+// source file is run through Process and the resulting EPUB is written under dstDir,
+// named after the source. Debounced conversions for distinct files can fire concurrently,
+// so newProcessor must hand back a fresh Processor (its own p.tmpDir) per call - reusing
+// one Processor across conversions would let them race on the same staging directory.
+// It is not a CLI entry point, and no command-line flag in this repository constructs a
+// Watcher with it; callers pass it to NewWatcher themselves.
+func ConvertOnWatch(newProcessor func() *Processor, dstDir string) WatchConvertFunc {
+	return func(path string) error {
+		p := newProcessor()
+		if err := p.Process(path); err != nil {
+			return errors.Wrapf(err, "unable to process %s", path)
+		}
+		base := filepath.Base(path)
+		fname := filepath.Join(dstDir, strings.TrimSuffix(base, filepath.Ext(base))+".epub")
+		return p.FinalizeEPUB(fname)
+	}
+}
+
+func isWatchedSource(path string) bool {
+	lower := strings.ToLower(path)
+	for _, ext := range watchExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}